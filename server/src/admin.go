@@ -0,0 +1,148 @@
+package main
+
+import (
+    "bufio"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "os"
+    "strconv"
+    "strings"
+)
+
+// adminToken gates the whole /admin/ subtree. An empty token (the
+// default, when ADMIN_TOKEN isn't set) disables the subtree entirely -
+// an admin API with no auth configured is worse than no admin API.
+var adminToken = os.Getenv("ADMIN_TOKEN")
+
+const defaultTailLines = 100
+
+// Request against /admin/loggers[/{app}/{action}]
+func HandleAdminRequest(w http.ResponseWriter, r *http.Request) {
+    if adminToken == "" {
+        ReturnError(w, r, "Not Found", http.StatusNotFound)
+        return
+    }
+    auth := r.Header.Get("Authorization")
+    if auth != "Bearer "+adminToken {
+        ReturnError(w, r, "Missing/invalid admin token", http.StatusUnauthorized)
+        return
+    }
+
+    path := strings.TrimPrefix(r.URL.Path, "/admin/")
+    if path == "loggers" {
+        handleListLoggers(w, r)
+        return
+    }
+
+    rest := strings.TrimPrefix(path, "loggers/")
+    parts := strings.SplitN(rest, "/", 2)
+    if !strings.HasPrefix(path, "loggers/") || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+        ReturnError(w, r, "Not Found", http.StatusNotFound)
+        return
+    }
+    appname, action := parts[0], parts[1]
+    logger := loggers.get(appname)
+    if logger == nil {
+        ReturnError(w, r, "Unknown app", http.StatusNotFound)
+        return
+    }
+
+    switch action {
+    case "reload":
+        handleAdminAction(w, r, http.MethodPost, logger, AdminRequest{Kind: "reload"})
+    case "rotate":
+        handleAdminAction(w, r, http.MethodPost, logger, AdminRequest{Kind: "rotate"})
+    case "tail":
+        n := defaultTailLines
+        if raw := r.URL.Query().Get("n"); raw != "" {
+            if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+                n = parsed
+            }
+        }
+        handleAdminAction(w, r, http.MethodGet, logger, AdminRequest{Kind: "tail", TailLines: n})
+    default:
+        ReturnError(w, r, "Not Found", http.StatusNotFound)
+    }
+}
+
+func handleListLoggers(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        ReturnError(w, r, "GET only", http.StatusMethodNotAllowed)
+        return
+    }
+    apps := loggers.apps()
+    infos := make([]LoggerInfo, 0, len(apps))
+    for _, appname := range apps {
+        logger := loggers.get(appname)
+        if logger == nil {
+            continue
+        }
+        res := askLogger(logger, AdminRequest{Kind: "info"})
+        infos = append(infos, res.Info)
+    }
+    writeJSON(w, r, infos)
+}
+
+func handleAdminAction(w http.ResponseWriter, r *http.Request, method string, logger *Logger, req AdminRequest) {
+    if r.Method != method {
+        ReturnError(w, r, method+" only", http.StatusMethodNotAllowed)
+        return
+    }
+    res := askLogger(logger, req)
+    if res.Err != nil {
+        ReturnError(w, r, res.Err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if req.Kind == "tail" {
+        writeJSON(w, r, struct {
+            Lines []string `json:"lines"`
+        }{res.Lines})
+        return
+    }
+    fmt.Fprint(w, "OK")
+}
+
+// askLogger sends req on logger.Admin and waits for the reply, the same
+// request/Done-channel pattern HandleLoglevelRequest uses for writes.
+func askLogger(logger *Logger, req AdminRequest) AdminResponse {
+    done := make(chan AdminResponse)
+    req.Done = done
+    logger.Admin <- req
+    return <-done
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+    buf, err := json.Marshal(v)
+    if err != nil {
+        ReturnError(w, r, "Error marshalling response", http.StatusInternalServerError)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.Write(buf)
+}
+
+// tailFile returns the last n lines of path. It reads the whole file -
+// fine for admin/debugging use on the modest log files this service
+// writes, not intended for huge files.
+func tailFile(path string, n int) ([]string, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    var lines []string
+    scanner := bufio.NewScanner(file)
+    scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+    for scanner.Scan() {
+        lines = append(lines, scanner.Text())
+        if len(lines) > n {
+            lines = lines[1:]
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+    return lines, nil
+}
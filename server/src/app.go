@@ -1,6 +1,8 @@
 package main
 
 import (
+    "bufio"
+    "bytes"
     "encoding/json"
     "errors"
     "io"
@@ -10,10 +12,15 @@ import (
     "net/http"
     "os"
     "path/filepath"
+    "reflect"
+    "sort"
+    "strconv"
     "strings"
+    "sync"
     "time"
 
     "github.com/golang/gddo/httputil/header"
+    "github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // loglevel log item
@@ -53,6 +60,15 @@ type LoggerConfig struct {
     App string `json:"app"`
     Dir string `json:"dir"`
     Secret string `json:"secret"`
+    Driver string `json:"driver"`
+    DriverOpts map[string]interface{} `json:"driver_opts"`
+    Rotate RotatorConfig `json:"rotate"`
+    // Sentry envelope ingestion (see sentry.go); unset unless the app
+    // also receives crash envelopes.
+    SentryDsnKey string `json:"sentry_dsn_key"`
+    SpoolDir string `json:"spool_dir"`
+    UpstreamDsn string `json:"upstream_dsn"`
+    SendRate float64 `json:"send_rate"`
 }
 
 var debug = false
@@ -79,6 +95,9 @@ func main() {
     log.Printf("Log to %s, config in %s", logdir, confdir)
 
     http.HandleFunc("/loglevel/", HandleLoglevelRequest)
+    http.HandleFunc("/api/", HandleSentryEnvelope)
+    http.HandleFunc("/admin/", HandleAdminRequest)
+    http.Handle("/metrics", promhttp.Handler())
     http.HandleFunc("/", HandleRootRequest)
     go requestHandler()
     log.Print("Running on :8080")
@@ -95,37 +114,53 @@ func ReturnError(w http.ResponseWriter, r *http.Request, message string, code in
 
 // Request in Loglevel format
 func HandleLoglevelRequest(w http.ResponseWriter, r *http.Request) {
-    // limit size = 10MB
-    r.Body = http.MaxBytesReader(w, r.Body, 1048576*10)
+    mimetype, _ := header.ParseValueAndParams(r.Header, "Content-Type")
+    if mimetype != "application/x-ndjson" {
+        // limit size = 10MB. NDJSON bodies are capped per-line instead
+        // (see streamNdjsonResponse) so a single connection can carry far
+        // more than this without buffering it all in memory.
+        r.Body = http.MaxBytesReader(w, r.Body, 1048576*10)
+    }
 
-    message,code := getLoglevelResponse(r)
+    start := time.Now()
+    message,code,appname := getLoglevelResponse(r)
+    requestsTotal.WithLabelValues(appname, strconv.Itoa(code)).Inc()
+    requestDuration.WithLabelValues(appname).Observe(time.Since(start).Seconds())
     if code == http.StatusOK {
         fmt.Fprint(w, message);
     } else {
         ReturnError(w, r, message, code)
     }
 }
-func getLoglevelResponse(r *http.Request) (string,int) {
+// getLoglevelResponse also returns the parsed app name (possibly "") so
+// HandleLoglevelRequest can label its metrics with it.
+func getLoglevelResponse(r *http.Request) (string,int,string) {
     if r.Method != http.MethodPost {
-        return "log accepts POST only", http.StatusMethodNotAllowed
+        return "log accepts POST only", http.StatusMethodNotAllowed, ""
     }
     mimetype, _ := header.ParseValueAndParams(r.Header, "Content-Type")
-    if mimetype != "application/json" {
-        return "Send me JSON!", http.StatusUnsupportedMediaType
+    if mimetype != "application/json" && mimetype != "application/x-ndjson" {
+        return "Send me JSON!", http.StatusUnsupportedMediaType, ""
     }
     auth := r.Header.Get("Authorization")
     if len(auth) < 7 || auth[0:7] != "Bearer " {
-        return "Missing/non-bearer authorization", http.StatusUnauthorized
+        return "Missing/non-bearer authorization", http.StatusUnauthorized, ""
     }
     authtoken := auth[7:]
     appname := r.URL.Path[10:] // /loglevel/...
     slix := strings.Index(appname,"/")
     if slix > -1 || len(appname) == 0 {
-        return "Invalid/missing app name", http.StatusNotFound
+        return "Invalid/missing app name", http.StatusNotFound, ""
     }
     if debug {
         log.Printf("POST %s (token %s)", appname, authtoken)
     }
+
+    if mimetype == "application/x-ndjson" {
+        message, code := streamNdjsonResponse(r.Body, appname, authtoken)
+        return message, code, appname
+    }
+
     // with help from https://www.alexedwards.net/blog/how-to-properly-parse-a-json-request-body
     dec := json.NewDecoder(r.Body)
     // disallow additional fields
@@ -140,50 +175,54 @@ func getLoglevelResponse(r *http.Request) (string,int) {
         switch {
         // Catch any syntax errors in the JSON
         case errors.As(err, &syntaxError):
-            return "badly formed JSON", http.StatusBadRequest
+            return "badly formed JSON", http.StatusBadRequest, appname
 
         // In some circumstances Decode() may also return an
         // io.ErrUnexpectedEOF error for syntax errors in the JSON. There
         // is an open issue regarding this at
         // https://github.com/golang/go/issues/25956.
         case errors.Is(err, io.ErrUnexpectedEOF):
-            return "badly formed JSON", http.StatusBadRequest
+            return "badly formed JSON", http.StatusBadRequest, appname
 
         // Catch any type errors
         case errors.As(err, &unmarshalTypeError):
-            return "JSON type error", http.StatusBadRequest
+            return "JSON type error", http.StatusBadRequest, appname
 
         // Catch the error caused by extra unexpected fields in the request
-        // body. 
+        // body.
         case strings.HasPrefix(err.Error(), "json: unknown field "):
-            return "JSON with unknown fields", http.StatusBadRequest
+            return "JSON with unknown fields", http.StatusBadRequest, appname
 
         // An io.EOF error is returned by Decode() if the request body is
         // empty.
         case errors.Is(err, io.EOF):
-            return "Empty request", http.StatusBadRequest
+            return "Empty request", http.StatusBadRequest, appname
 
         // Catch the error caused by the request body being too large. Again
         // there is an open issue regarding turning this into a sentinel
         // error at https://github.com/golang/go/issues/30715.
         case err.Error() == "http: request body too large":
-            return "Request too large", http.StatusRequestEntityTooLarge
+            return "Request too large", http.StatusRequestEntityTooLarge, appname
 
         // Otherwise default to logging the error and sending a 500 Internal
         // Server Error response.
         default:
             log.Println(err.Error())
-            return http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError
+            return http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError, appname
         }
     }
 
-    // Call decode again, using a pointer to an empty anonymous struct as 
-    // the destination. If the request body only contained a single JSON 
-    // object this will return an io.EOF error. So if we get anything else, 
+    // Call decode again, using a pointer to an empty anonymous struct as
+    // the destination. If the request body only contained a single JSON
+    // object this will return an io.EOF error. So if we get anything else,
     // we know that there is additional data in the request body.
     err = dec.Decode(&struct{}{})
     if err != io.EOF {
-        return "Extra data after body", http.StatusBadRequest
+        return "Extra data after body", http.StatusBadRequest, appname
+    }
+
+    for i := range ls.Logs {
+        itemsReceived.WithLabelValues(appname, ls.Logs[i].Level).Inc()
     }
 
     // reply channel - message and http status
@@ -196,7 +235,98 @@ func getLoglevelResponse(r *http.Request) (string,int) {
     }
     requests <- req
     res := <-done
-    return res.Message, res.Code
+    return res.Message, res.Code, appname
+}
+
+// ndjson items are dispatched to the per-app Requests channel in chunks
+// rather than decoding the whole body into one slice, so a streamed
+// upload of millions of events doesn't have to fit in memory at once.
+const ndjsonChunkSize = 500
+
+// a single NDJSON line over this size is rejected rather than read in
+// full, so one oversized line can't blow out the scanner's buffer.
+const ndjsonMaxLineBytes = 1048576 // 1MB
+
+// ndjsonSummary is the JSON body returned for application/x-ndjson
+// uploads: a count rather than a single message/code, since a stream can
+// partially succeed. Clients can use accepted as a retry offset.
+type ndjsonSummary struct {
+    Accepted int `json:"accepted"`
+    Rejected int `json:"rejected"`
+    FirstError string `json:"first_error"`
+}
+
+// streamNdjsonResponse reads body as newline-delimited JSON LoglevelItems
+// and dispatches them to the appname logger in chunks of ndjsonChunkSize,
+// instead of buffering the whole request like getLoglevelResponse's
+// application/json path does. Malformed or oversized lines are counted
+// as rejected and skipped; dispatch failures (bad token, unconfigured
+// app, sink error) stop the stream early since later chunks would fail
+// the same way. Always returns http.StatusOK - the per-item/per-chunk
+// outcome is reported in the summary body instead.
+func streamNdjsonResponse(body io.Reader, appname string, authtoken string) (string, int) {
+    // bufio.Reader.ReadBytes has no line-length ceiling of its own - it
+    // keeps growing its buffer until it finds '\n', so an unterminated
+    // multi-hundred-MB line would be read in full before the
+    // ndjsonMaxLineBytes check below ever saw it. Scanner.Buffer caps the
+    // buffer it will grow to and fails fast with bufio.ErrTooLong instead.
+    scanner := bufio.NewScanner(body)
+    scanner.Buffer(make([]byte, 64*1024), ndjsonMaxLineBytes)
+    summary := ndjsonSummary{}
+    batch := make([]LoglevelItem, 0, ndjsonChunkSize)
+
+    flush := func() bool {
+        if len(batch) == 0 {
+            return true
+        }
+        done := make(chan LogResponse)
+        requests <- LogRequest{Appname: appname, Token: authtoken, Items: batch, Done: done}
+        res := <-done
+        if res.Code != http.StatusOK {
+            if summary.FirstError == "" {
+                summary.FirstError = res.Message
+            }
+            summary.Rejected += len(batch)
+            batch = batch[:0]
+            return false
+        }
+        for i := range batch {
+            itemsReceived.WithLabelValues(appname, batch[i].Level).Inc()
+        }
+        summary.Accepted += len(batch)
+        batch = batch[:0]
+        return true
+    }
+
+    for scanner.Scan() {
+        trimmed := bytes.TrimSpace(scanner.Bytes())
+        if len(trimmed) == 0 {
+            continue
+        }
+        var item LoglevelItem
+        if uerr := json.Unmarshal(trimmed, &item); uerr != nil {
+            summary.Rejected++
+            if summary.FirstError == "" {
+                summary.FirstError = uerr.Error()
+            }
+            continue
+        }
+        batch = append(batch, item)
+        if len(batch) >= ndjsonChunkSize && !flush() {
+            break
+        }
+    }
+    if err := scanner.Err(); err != nil && summary.FirstError == "" {
+        // includes bufio.ErrTooLong for a line over ndjsonMaxLineBytes
+        summary.FirstError = err.Error()
+    }
+    flush()
+
+    buf, err := json.Marshal(summary)
+    if err != nil {
+        return "Error marshalling response", http.StatusInternalServerError
+    }
+    return string(buf), http.StatusOK
 }
 
 // Logger type / internal data
@@ -204,36 +334,69 @@ type Logger struct{
     Appname string
     Token string
     Requests chan LogRequest
+    Admin chan AdminRequest
     Configured bool
     ConfigLastCheck time.Time
     ConfigFile string
     Config LoggerConfig
     Logdir string
-    CreateLast time.Time
     WriteLast time.Time
     NeedsFlush bool
-    LogFile *os.File
+    Sink LogSink
 }
 // don't force dispatch thread to wait for back-end logger thread
 // (most of the time)
 const REQUEST_BUFFER_SIZE = 100
+const ADMIN_BUFFER_SIZE = 10
+
+// registry of live loggers, promoted out of requestHandler's local scope
+// so the /admin/ handlers (see admin.go) can look them up safely.
+type LoggerRegistry struct {
+    mu sync.Mutex
+    byApp map[string]*Logger
+}
+
+var loggers = &LoggerRegistry{byApp: make(map[string]*Logger)}
+
+func (this *LoggerRegistry) getOrCreate(appname string) *Logger {
+    this.mu.Lock()
+    defer this.mu.Unlock()
+    logger := this.byApp[appname]
+    if logger == nil {
+        log.Printf("Create logger %s\n", appname)
+        logger = new(Logger)
+        logger.Appname = appname
+        logger.Requests = make(chan LogRequest, REQUEST_BUFFER_SIZE)
+        logger.Admin = make(chan AdminRequest, ADMIN_BUFFER_SIZE)
+        go loggerHandler(logger)
+        this.byApp[appname] = logger
+        openLoggers.Inc()
+    }
+    return logger
+}
+
+func (this *LoggerRegistry) get(appname string) *Logger {
+    this.mu.Lock()
+    defer this.mu.Unlock()
+    return this.byApp[appname]
+}
+
+func (this *LoggerRegistry) apps() []string {
+    this.mu.Lock()
+    defer this.mu.Unlock()
+    names := make([]string, 0, len(this.byApp))
+    for name := range this.byApp {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
 
 // call only once as go routine!
 func requestHandler() {
-    loggers := make(map[string]*Logger)
-
     for true  {
         req := <-requests
-
-        logger := loggers[req.Appname]
-        if logger == nil {
-            log.Printf("Create logger %s\n", req.Appname)
-            logger = new(Logger)
-            logger.Appname = req.Appname
-            logger.Requests = make(chan LogRequest, REQUEST_BUFFER_SIZE)
-            go loggerHandler(logger)
-            loggers[req.Appname] = logger
-        }
+        logger := loggers.getOrCreate(req.Appname)
         logger.Requests <- req
     }
 }
@@ -241,7 +404,6 @@ func requestHandler() {
 // 1 minute
 const CACHE_CONFIG_HOURS = 1.0/60 // 1 minute
 const FLUSH_HOURS = 1.0/60/2 // 30 seconds
-const ROTATE_HOURS = 24.0
 // RFC3339 with ms accuracy
 const RFC3339MS = "2006-01-02T15:04:05.000Z07:00"
 
@@ -249,71 +411,93 @@ const RFC3339MS = "2006-01-02T15:04:05.000Z07:00"
 func loggerHandler(logger *Logger) {
     for true {
         // TODO call HandleRequest after a time with no items to force sync/close
-        req := <-logger.Requests
-
-        if debug {
-            log.Printf("Log %s: %d items\n", req.Appname, len(req.Items))
-        }
-        msg,code := logger.HandleRequest(req)
-        req.Done <- LogResponse{
-            Message:msg,
-            Code: code,
+        select {
+        case req := <-logger.Requests:
+            channelDepth.WithLabelValues(req.Appname).Set(float64(len(logger.Requests)))
+            if debug {
+                log.Printf("Log %s: %d items\n", req.Appname, len(req.Items))
+            }
+            msg,code := logger.HandleRequest(req)
+            req.Done <- LogResponse{
+                Message:msg,
+                Code: code,
+            }
+        case admin := <-logger.Admin:
+            admin.Done <- logger.HandleAdmin(admin)
         }
     }
 }
-func (this *Logger) HandleRequest(req LogRequest) (string,int) {
-    now := time.Now()
-    // read or update Config; check Logdir exists
-    if this.ConfigLastCheck.IsZero() ||
-       now.Sub(this.ConfigLastCheck).Hours() > CACHE_CONFIG_HOURS {
-        this.ConfigFile = filepath.Join(confdir, req.Appname+".json")
-        if debug {
-            log.Printf("(Re)Read config %s", this.ConfigFile)
-        }
-        this.ConfigLastCheck = now
-        rawconfig,err := ioutil.ReadFile(this.ConfigFile)
-        if err != nil {
-            log.Printf("Error reading config %s for %s: %s", this.ConfigFile, req.Appname, err)
-            this.Configured = false
-        } else {
-            var newConfig LoggerConfig
-            err = json.Unmarshal(rawconfig, &newConfig)
+// refreshConfig (re)reads conf/{appname}.json into this.Config, unless
+// it was checked less than CACHE_CONFIG_HOURS ago and force is false.
+// force is used by the /admin/loggers/{app}/reload endpoint to bypass
+// that cache.
+func (this *Logger) refreshConfig(appname string, force bool, now time.Time) {
+    if !force && !this.ConfigLastCheck.IsZero() &&
+       now.Sub(this.ConfigLastCheck).Hours() <= CACHE_CONFIG_HOURS {
+        return
+    }
+    this.ConfigFile = filepath.Join(confdir, appname+".json")
+    if debug {
+        log.Printf("(Re)Read config %s", this.ConfigFile)
+    }
+    this.ConfigLastCheck = now
+    rawconfig,err := ioutil.ReadFile(this.ConfigFile)
+    if err != nil {
+        log.Printf("Error reading config %s for %s: %s", this.ConfigFile, appname, err)
+        this.Configured = false
+        return
+    }
+    var newConfig LoggerConfig
+    err = json.Unmarshal(rawconfig, &newConfig)
+    if err != nil {
+        log.Printf("Error parsing config %s for %s: %s", this.ConfigFile, appname, err)
+        this.Configured = false
+        return
+    }
+    if debug {
+        log.Printf("Config for %s: dir %s", appname, newConfig.Dir)
+    }
+    if newConfig.Dir == "" {
+        newConfig.Dir = appname
+    }
+    this.Configured = true
+    dirChanged := newConfig.Dir != this.Config.Dir
+    // Driver/DriverOpts/Rotate are all baked into the Sink (and, for the
+    // file driver, its Rotator) at creation time, so a running logger
+    // needs its sink torn down and rebuilt when any of them change too -
+    // not just Dir - or a reload only ever takes effect on the next
+    // process restart.
+    sinkConfigChanged := newConfig.Driver != this.Config.Driver ||
+        !reflect.DeepEqual(newConfig.DriverOpts, this.Config.DriverOpts) ||
+        newConfig.Rotate != this.Config.Rotate
+    if dirChanged || sinkConfigChanged {
+        this.closeSink()
+    }
+    if dirChanged {
+        log.Printf("Set log dir %s for %s", newConfig.Dir, appname)
+        this.Logdir =  filepath.Join(logdir, newConfig.Dir)
+        linfo,err := os.Stat(this.Logdir)
+        if err != nil && os.IsNotExist(err) {
+            err = os.Mkdir(this.Logdir, 0775)
             if err != nil {
-                log.Printf("Error parsing config %s for %s: %s", this.ConfigFile, req.Appname, err)
+                log.Printf("Could not create new log dir %s for %s: %s", this.Logdir, appname, err)
                 this.Configured = false
-            } else {
-                if debug {
-                    log.Printf("Config for %s: dir %s", req.Appname, newConfig.Dir)
-                }
-                if newConfig.Dir == "" {
-                    newConfig.Dir = req.Appname
-                }
-                this.Configured = true
-                if newConfig.Dir != this.Config.Dir {
-                    // close
-                    this.CloseLogFile()
-                    log.Printf("Set log dir %s for %s", newConfig.Dir, req.Appname)
-                    this.Logdir =  filepath.Join(logdir, newConfig.Dir)
-                    linfo,err := os.Stat(this.Logdir)
-                    if err != nil && os.IsNotExist(err) {
-                        err = os.Mkdir(this.Logdir, 0775)
-                        if err != nil {
-                            log.Printf("Could not create new log dir %s for %s: %s", this.Logdir, req.Appname, err)
-                            this.Configured = false
-                        }
-                        log.Printf("Created log dir %s for %s", this.Logdir, req.Appname)
-                    } else if err != nil {
-                        log.Printf("Problem with log dir %s for %s: %s", this.Logdir, req.Appname, err)
-                        this.Configured = false
-                    } else if ! linfo.IsDir() {
-                        log.Printf("Log dir %s for %s is not a directory", this.Logdir, req.Appname)
-                        this.Configured = false
-                    }
-                }
-                this.Config = newConfig
             }
+            log.Printf("Created log dir %s for %s", this.Logdir, appname)
+        } else if err != nil {
+            log.Printf("Problem with log dir %s for %s: %s", this.Logdir, appname, err)
+            this.Configured = false
+        } else if ! linfo.IsDir() {
+            log.Printf("Log dir %s for %s is not a directory", this.Logdir, appname)
+            this.Configured = false
         }
     }
+    this.Config = newConfig
+}
+
+func (this *Logger) HandleRequest(req LogRequest) (string,int) {
+    now := time.Now()
+    this.refreshConfig(req.Appname, false, now)
     if  ! this.Configured {
         return "Logger not configured", http.StatusNotFound
     }
@@ -321,14 +505,11 @@ func (this *Logger) HandleRequest(req LogRequest) (string,int) {
         log.Printf("invalid token for log %s", req.Appname)
         return "Invalid token", http.StatusUnauthorized
     }
-    if this.LogFile != nil && now.Sub(this.CreateLast).Hours() > ROTATE_HOURS {
-        this.CloseLogFile()
-    }
-    if this.LogFile != nil && this.NeedsFlush && now.Sub(this.WriteLast).Hours() > FLUSH_HOURS {
-        err := this.LogFile.Sync()
+    if this.Sink != nil && this.NeedsFlush && now.Sub(this.WriteLast).Hours() > FLUSH_HOURS {
+        err := this.Sink.Flush()
         if err != nil {
             // trigger reopen
-            this.CloseLogFile()
+            this.closeSink()
         }
         this.NeedsFlush = false
     }
@@ -337,34 +518,23 @@ func (this *Logger) HandleRequest(req LogRequest) (string,int) {
         return "OK",http.StatusOK
     }
 
-    if this.LogFile == nil {
-        filename := now.UTC().Format(time.RFC3339) + ".log"
-        path := filepath.Join(this.Logdir, filename)
-        log.Printf("New log file %s for %s", path, this.Appname)
-        file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0755)
+    if this.Sink == nil {
+        sink, err := newSink(this.Config.Driver, this.Appname, this.Logdir, this.Config.DriverOpts, this.Config.Rotate)
         if err != nil {
-            log.Printf("Error opening log %s: %s", path, err)
-            this.LogFile = nil
+            log.Printf("Error creating %q sink for %s: %s", this.Config.Driver, this.Appname, err)
             return "Could not create logfile", http.StatusInternalServerError
         }
-        this.LogFile = file
-        this.CreateLast = now
+        this.Sink = sink
     }
     for i:= 0; i<len( req.Items ); i++ {
         req.Items[i].ServerTime = now.UTC().Format(RFC3339MS)
-        buf,err := json.Marshal( req.Items[i] )
-        if err != nil {
-            log.Printf("Error marshalling log item: %s", err)
-            return "Error marshalling log item", http.StatusInternalServerError
-        }
-        _,err = this.LogFile.Write(buf)
-       if err != nil {
-           log.Printf("Error writing log item: %s", err)
-           this.CloseLogFile()
-           return "Error writing log item", http.StatusInternalServerError
-       }
-
-       _,_ = this.LogFile.Write([]byte("\n"))
+    }
+    n, err := this.Sink.WriteBatch(req.Items)
+    bytesWritten.WithLabelValues(this.Appname).Add(float64(n))
+    if err != nil {
+        log.Printf("Error writing log items for %s: %s", this.Appname, err)
+        this.closeSink()
+        return "Error writing log item", http.StatusInternalServerError
     }
     if ! this.NeedsFlush {
         this.NeedsFlush = true
@@ -373,17 +543,78 @@ func (this *Logger) HandleRequest(req LogRequest) (string,int) {
     return "OK",http.StatusOK
 }
 
-func (this *Logger) CloseLogFile() {
-    if this.LogFile != nil {
-        err := this.LogFile.Sync()
+func (this *Logger) closeSink() {
+    if this.Sink != nil {
+        err := this.Sink.Close()
         if err != nil {
-            log.Printf("Error syncing logfile for %s: %s", this.Appname, err)
+            log.Printf("Error closing sink for %s: %s", this.Appname, err)
         }
-        err = this.LogFile.Close()
+        this.Sink = nil
+    }
+}
+
+// AdminRequest is sent on a Logger's Admin channel by the /admin/
+// handlers (see admin.go), mirroring the LogRequest/LogResponse pattern
+// used for ordinary log writes so logger state is only ever touched by
+// its own loggerHandler goroutine.
+type AdminRequest struct {
+    Kind string // "info", "reload", "rotate", "tail"
+    TailLines int
+    Done chan AdminResponse
+}
+
+type AdminResponse struct {
+    Info LoggerInfo
+    Lines []string
+    Err error
+}
+
+// LoggerInfo is the JSON shape returned by GET /admin/loggers.
+type LoggerInfo struct {
+    Appname string `json:"app"`
+    Configured bool `json:"configured"`
+    FilePath string `json:"file_path"`
+    BytesWritten int64 `json:"bytes_written"`
+    WriteLast time.Time `json:"write_last"`
+    BacklogDepth int `json:"backlog_depth"`
+}
+
+func (this *Logger) HandleAdmin(req AdminRequest) AdminResponse {
+    switch req.Kind {
+    case "reload":
+        this.refreshConfig(this.Appname, true, time.Now())
+        return AdminResponse{}
+    case "rotate":
+        rotatable, ok := this.Sink.(Rotatable)
+        if !ok {
+            return AdminResponse{Err: fmt.Errorf("driver %q does not support rotation", this.Config.Driver)}
+        }
+        if err := rotatable.ForceRotate(); err != nil {
+            return AdminResponse{Err: err}
+        }
+        return AdminResponse{}
+    case "tail":
+        inspectable, ok := this.Sink.(Inspectable)
+        if !ok || inspectable.Path() == "" {
+            return AdminResponse{Err: fmt.Errorf("driver %q has no tailable file", this.Config.Driver)}
+        }
+        lines, err := tailFile(inspectable.Path(), req.TailLines)
         if err != nil {
-            log.Printf("Error closing logfile for %s: %s", this.Appname, err)
+            return AdminResponse{Err: err}
+        }
+        return AdminResponse{Lines: lines}
+    default: // "info"
+        info := LoggerInfo{
+            Appname: this.Appname,
+            Configured: this.Configured,
+            WriteLast: this.WriteLast,
+            BacklogDepth: len(this.Requests),
+        }
+        if inspectable, ok := this.Sink.(Inspectable); ok {
+            info.FilePath = inspectable.Path()
+            info.BytesWritten = inspectable.BytesWritten()
         }
-        this.LogFile = nil
+        return AdminResponse{Info: info}
     }
 }
 
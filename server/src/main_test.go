@@ -0,0 +1,43 @@
+package main
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+// TestMain gives every test in this package a working confdir/logdir and a
+// running requestHandler goroutine, mirroring what main() sets up for the
+// real server, so tests can drive the full HTTP write path instead of only
+// the Logger/LogSink layer.
+func TestMain(m *testing.M) {
+    var err error
+    confdir, err = os.MkdirTemp("", "httplog-conf")
+    if err != nil {
+        panic(err)
+    }
+    logdir, err = os.MkdirTemp("", "httplog-logs")
+    if err != nil {
+        panic(err)
+    }
+    go requestHandler()
+    os.Exit(m.Run())
+}
+
+// writeTestConfig drops conf/{appname}.json into confdir, the same file
+// Logger.refreshConfig reads, so tests can drive a logger through the
+// real HTTP handlers instead of poking at its fields directly.
+func writeTestConfig(t *testing.T, appname string, cfg LoggerConfig) {
+    t.Helper()
+    if cfg.Dir == "" {
+        cfg.Dir = appname
+    }
+    buf, err := json.Marshal(cfg)
+    if err != nil {
+        t.Fatalf("marshalling test config for %s: %s", appname, err)
+    }
+    if err := os.WriteFile(filepath.Join(confdir, appname+".json"), buf, 0664); err != nil {
+        t.Fatalf("writing test config for %s: %s", appname, err)
+    }
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var itemsReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name: "httplog_items_received_total",
+    Help: "Loglevel items received, by app and level.",
+}, []string{"app", "level"})
+
+var bytesWritten = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name: "httplog_bytes_written_total",
+    Help: "Bytes written to a logger's sink, by app.",
+}, []string{"app"})
+
+var requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name: "httplog_requests_total",
+    Help: "HTTP requests to /loglevel/, by app and response code.",
+}, []string{"app", "code"})
+
+var requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+    Name: "httplog_request_duration_seconds",
+    Help: "/loglevel/ request handling latency, by app.",
+}, []string{"app"})
+
+var openLoggers = promauto.NewGauge(prometheus.GaugeOpts{
+    Name: "httplog_open_loggers",
+    Help: "Number of live per-app loggers.",
+})
+
+var channelDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+    Name: "httplog_channel_depth",
+    Help: "Items queued on a logger's buffered Requests channel, by app.",
+}, []string{"app"})
+
+var rotationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name: "httplog_rotations_total",
+    Help: "Log file rotations, by app.",
+}, []string{"app"})
@@ -0,0 +1,53 @@
+package main
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+    "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestMetricsAdvanceAfterPost posts a loglevel batch through the real
+// HTTP handler and confirms the counters it should have touched advance,
+// both directly and via a /metrics scrape.
+func TestMetricsAdvanceAfterPost(t *testing.T) {
+    appname := "metrics-app"
+    writeTestConfig(t, appname, LoggerConfig{Secret: "tok", Driver: "file"})
+
+    before := testutil.ToFloat64(requestsTotal.WithLabelValues(appname, "200"))
+
+    body := `{"logs":[{"message":"hi","level":"info"}]}`
+    req := httptest.NewRequest(http.MethodPost, "/loglevel/"+appname, strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("Authorization", "Bearer tok")
+    rec := httptest.NewRecorder()
+    HandleLoglevelRequest(rec, req)
+    if rec.Code != http.StatusOK {
+        t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+    }
+
+    after := testutil.ToFloat64(requestsTotal.WithLabelValues(appname, "200"))
+    if after != before+1 {
+        t.Fatalf("expected httplog_requests_total{app=%q,code=200} to advance by 1, got %v -> %v", appname, before, after)
+    }
+
+    received := testutil.ToFloat64(itemsReceived.WithLabelValues(appname, "info"))
+    if received < 1 {
+        t.Fatalf("expected httplog_items_received_total{app=%q,level=info} to advance, got %v", appname, received)
+    }
+
+    written := testutil.ToFloat64(bytesWritten.WithLabelValues(appname))
+    if written <= 0 {
+        t.Fatalf("expected httplog_bytes_written_total{app=%q} to advance, got %v", appname, written)
+    }
+
+    metricsReq := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+    metricsRec := httptest.NewRecorder()
+    promhttp.Handler().ServeHTTP(metricsRec, metricsReq)
+    if !strings.Contains(metricsRec.Body.String(), `httplog_requests_total{app="metrics-app",code="200"}`) {
+        t.Fatalf("expected a /metrics scrape to include the per-app request counter")
+    }
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+    "compress/gzip"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+)
+
+// matches the old fixed ROTATE_HOURS behaviour when MaxAgeHours is unset
+const defaultMaxAgeHours = 24.0
+
+// RotatorConfig controls when a file-backed sink rotates to a new
+// backing file, and how old segments are cleaned up afterwards. A zero
+// MaxSizeMB/MaxBackups disables that limit; a zero MaxAgeHours falls
+// back to defaultMaxAgeHours rather than disabling age-based rotation.
+type RotatorConfig struct {
+    MaxSizeMB   int     `json:"max_size_mb"`
+    MaxAgeHours float64 `json:"max_age_hours"`
+    MaxBackups  int     `json:"max_backups"`
+    Compress    bool    `json:"compress"`
+    LocalTime   bool    `json:"local_time"`
+}
+
+// Rotator owns a single growing log file for an app, rotating it out by
+// size and/or age and pruning old backups in the background, the way
+// most log-shipping sidecars do.
+type Rotator struct {
+    appname string
+    dir     string
+    config  RotatorConfig
+
+    file      *os.File
+    createdAt time.Time
+    size      int64
+}
+
+func NewRotator(appname string, dir string, config RotatorConfig) *Rotator {
+    return &Rotator{appname: appname, dir: dir, config: config}
+}
+
+func (this *Rotator) currentPath() string {
+    return filepath.Join(this.dir, this.appname+".log")
+}
+
+func (this *Rotator) maxAgeHours() float64 {
+    if this.config.MaxAgeHours > 0 {
+        return this.config.MaxAgeHours
+    }
+    return defaultMaxAgeHours
+}
+
+func (this *Rotator) now() time.Time {
+    if this.config.LocalTime {
+        return time.Now()
+    }
+    return time.Now().UTC()
+}
+
+func (this *Rotator) open() error {
+    path := this.currentPath()
+    file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0755)
+    if err != nil {
+        return err
+    }
+    info, err := file.Stat()
+    if err != nil {
+        file.Close()
+        return err
+    }
+    log.Printf("New log file %s for %s", path, this.appname)
+    this.file = file
+    this.size = info.Size()
+    this.createdAt = this.now()
+    return nil
+}
+
+// Write appends p to the current file, rotating first if the write
+// would exceed MaxSizeMB or the file is older than MaxAgeHours.
+func (this *Rotator) Write(p []byte) (int, error) {
+    if this.file == nil {
+        if err := this.open(); err != nil {
+            return 0, err
+        }
+    } else if this.needsRotation(len(p)) {
+        if err := this.rotate(); err != nil {
+            return 0, err
+        }
+        if err := this.open(); err != nil {
+            return 0, err
+        }
+    }
+    n, err := this.file.Write(p)
+    this.size += int64(n)
+    return n, err
+}
+
+func (this *Rotator) needsRotation(nextWrite int) bool {
+    if this.config.MaxSizeMB > 0 && this.size+int64(nextWrite) > int64(this.config.MaxSizeMB)*1024*1024 {
+        return true
+    }
+    return this.now().Sub(this.createdAt).Hours() > this.maxAgeHours()
+}
+
+// rotate closes the current file, renames it to a timestamped backup,
+// and kicks off compression/pruning in the background so the caller
+// isn't blocked on disk/gzip work.
+func (this *Rotator) rotate() error {
+    if this.file == nil {
+        return nil
+    }
+    err := this.file.Sync()
+    if cerr := this.file.Close(); err == nil {
+        err = cerr
+    }
+    this.file = nil
+    if err != nil {
+        return err
+    }
+    backupPath := filepath.Join(this.dir, fmt.Sprintf("%s-%s.log", this.appname, this.now().Format("20060102T150405.000Z0700")))
+    if err := os.Rename(this.currentPath(), backupPath); err != nil {
+        return err
+    }
+    log.Printf("Rotated log for %s to %s (size %d bytes)", this.appname, backupPath, this.size)
+    rotationsTotal.WithLabelValues(this.appname).Inc()
+    if this.config.Compress {
+        go this.compress(backupPath)
+    } else {
+        go this.prune()
+    }
+    return nil
+}
+
+func (this *Rotator) compress(path string) {
+    if err := gzipFile(path); err != nil {
+        log.Printf("Error compressing %s for %s: %s", path, this.appname, err)
+    }
+    this.prune()
+}
+
+func gzipFile(path string) error {
+    in, err := os.Open(path)
+    if err != nil {
+        return err
+    }
+    defer in.Close()
+    out, err := os.Create(path + ".gz")
+    if err != nil {
+        return err
+    }
+    gw := gzip.NewWriter(out)
+    if _, err := io.Copy(gw, in); err != nil {
+        gw.Close()
+        out.Close()
+        return err
+    }
+    if err := gw.Close(); err != nil {
+        out.Close()
+        return err
+    }
+    if err := out.Close(); err != nil {
+        return err
+    }
+    return os.Remove(path)
+}
+
+// prune removes backups (whether plain or gzipped) beyond MaxBackups
+// and older than MaxAgeHours.
+func (this *Rotator) prune() {
+    entries, err := os.ReadDir(this.dir)
+    if err != nil {
+        log.Printf("Error listing %s for rotation prune of %s: %s", this.dir, this.appname, err)
+        return
+    }
+    prefix := this.appname + "-"
+    var backups []string
+    for _, e := range entries {
+        name := e.Name()
+        if e.IsDir() || !strings.HasPrefix(name, prefix) {
+            continue
+        }
+        if !strings.HasSuffix(name, ".log") && !strings.HasSuffix(name, ".log.gz") {
+            continue
+        }
+        backups = append(backups, name)
+    }
+    sort.Strings(backups) // timestamp-named, so lexical order is chronological
+
+    cutoff := this.now().Add(-time.Duration(this.maxAgeHours() * float64(time.Hour)))
+    var toRemove []string
+    for i, name := range backups {
+        path := filepath.Join(this.dir, name)
+        info, err := os.Stat(path)
+        if err != nil {
+            continue
+        }
+        old := info.ModTime().Before(cutoff)
+        excess := this.config.MaxBackups > 0 && i < len(backups)-this.config.MaxBackups
+        if old || excess {
+            toRemove = append(toRemove, path)
+        }
+    }
+    for _, path := range toRemove {
+        if err := os.Remove(path); err != nil {
+            log.Printf("Error pruning backup %s for %s: %s", path, this.appname, err)
+        } else {
+            log.Printf("Pruned backup %s for %s", path, this.appname)
+        }
+    }
+}
+
+func (this *Rotator) Sync() error {
+    if this.file == nil {
+        return nil
+    }
+    return this.file.Sync()
+}
+
+func (this *Rotator) Close() error {
+    if this.file == nil {
+        return nil
+    }
+    err := this.file.Sync()
+    if cerr := this.file.Close(); err == nil {
+        err = cerr
+    }
+    this.file = nil
+    return err
+}
+
+// Path returns the current backing file, or "" if nothing has been
+// written yet.
+func (this *Rotator) Path() string {
+    if this.file == nil {
+        return ""
+    }
+    return this.currentPath()
+}
+
+// BytesWritten returns bytes written to the current file since it was
+// opened (not counting earlier, already-rotated segments).
+func (this *Rotator) BytesWritten() int64 {
+    return this.size
+}
+
+// ForceRotate rotates out the current file immediately, regardless of
+// size/age thresholds. Used by the /admin/loggers/{app}/rotate endpoint.
+func (this *Rotator) ForceRotate() error {
+    return this.rotate()
+}
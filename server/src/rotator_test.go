@@ -0,0 +1,69 @@
+package main
+
+import (
+    "bytes"
+    "os"
+    "strings"
+    "testing"
+    "time"
+)
+
+// TestRotatorRotatesOnSize forces rotation by shrinking MaxSizeMB to 1 and
+// writing more than that in a single call, then confirming the next write
+// rotates the oversized file out to a timestamped backup.
+func TestRotatorRotatesOnSize(t *testing.T) {
+    dir := t.TempDir()
+    r := NewRotator("sizeapp", dir, RotatorConfig{MaxSizeMB: 1})
+
+    big := bytes.Repeat([]byte("x"), 1100*1024) // over the 1MB threshold
+    if _, err := r.Write(big); err != nil {
+        t.Fatalf("initial write: %s", err)
+    }
+    if _, err := r.Write([]byte("next")); err != nil {
+        t.Fatalf("write that should trigger rotation: %s", err)
+    }
+
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        t.Fatalf("reading %s: %s", dir, err)
+    }
+    found := false
+    for _, e := range entries {
+        if strings.HasPrefix(e.Name(), "sizeapp-") && strings.HasSuffix(e.Name(), ".log") {
+            found = true
+        }
+    }
+    if !found {
+        t.Fatalf("expected a rotated backup file in %v, got %v", dir, entries)
+    }
+}
+
+// TestRotatorCompressesOnRotate confirms Compress:true gzips the rotated
+// backup. Compression runs in a background goroutine, so poll for it.
+func TestRotatorCompressesOnRotate(t *testing.T) {
+    dir := t.TempDir()
+    r := NewRotator("gzapp", dir, RotatorConfig{MaxSizeMB: 1, Compress: true})
+
+    big := bytes.Repeat([]byte("y"), 1100*1024)
+    if _, err := r.Write(big); err != nil {
+        t.Fatalf("initial write: %s", err)
+    }
+    if _, err := r.Write([]byte("next")); err != nil {
+        t.Fatalf("write that should trigger rotation: %s", err)
+    }
+
+    deadline := time.Now().Add(2 * time.Second)
+    for time.Now().Before(deadline) {
+        entries, err := os.ReadDir(dir)
+        if err != nil {
+            t.Fatalf("reading %s: %s", dir, err)
+        }
+        for _, e := range entries {
+            if strings.HasSuffix(e.Name(), ".log.gz") {
+                return
+            }
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+    t.Fatalf("expected a compressed backup in %s within the deadline", dir)
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "log"
+    "net/http"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+)
+
+// SentryEnvelopeHeader is the first line of a Sentry envelope: https://develop.sentry.dev/sdk/envelopes/
+type SentryEnvelopeHeader struct {
+    EventID string `json:"event_id"`
+    SentAt  string `json:"sent_at"`
+    Dsn     string `json:"dsn"`
+}
+
+// SentryItemHeader precedes each item's payload line in an envelope.
+type SentryItemHeader struct {
+    Type   string `json:"type"`
+    Length int    `json:"length"`
+}
+
+// Request in Sentry envelope format: POST /api/{project}/envelope/
+func HandleSentryEnvelope(w http.ResponseWriter, r *http.Request) {
+    // limit size = 10MB, same cap as /loglevel/
+    r.Body = http.MaxBytesReader(w, r.Body, 1048576*10)
+
+    message, code := getSentryEnvelopeResponse(r)
+    if code == http.StatusOK {
+        fmt.Fprint(w, message)
+    } else {
+        ReturnError(w, r, message, code)
+    }
+}
+
+// parseEnvelopeProject extracts {project} from /api/{project}/envelope/
+func parseEnvelopeProject(path string) string {
+    trimmed := strings.TrimPrefix(path, "/api/")
+    parts := strings.Split(trimmed, "/")
+    if len(parts) < 2 || parts[0] == "" || parts[1] != "envelope" {
+        return ""
+    }
+    return parts[0]
+}
+
+func getSentryEnvelopeResponse(r *http.Request) (string, int) {
+    if r.Method != http.MethodPost {
+        return "envelope accepts POST only", http.StatusMethodNotAllowed
+    }
+    project := parseEnvelopeProject(r.URL.Path)
+    if project == "" {
+        return "Invalid/missing project name", http.StatusNotFound
+    }
+    config, err := readAppConfig(project)
+    if err != nil {
+        log.Printf("Error reading config for envelope project %s: %s", project, err)
+        return "Unknown project", http.StatusNotFound
+    }
+    if config.SpoolDir == "" {
+        return "Project not configured for envelope ingestion", http.StatusNotFound
+    }
+
+    body, err := ioutil.ReadAll(r.Body)
+    if err != nil {
+        return "Error reading envelope", http.StatusBadRequest
+    }
+    headerLine := body
+    headerEnd := len(body)
+    if idx := bytes.IndexByte(body, '\n'); idx >= 0 {
+        headerLine = body[:idx]
+        headerEnd = idx + 1
+    }
+    var header SentryEnvelopeHeader
+    if err := json.Unmarshal(headerLine, &header); err != nil {
+        return "Badly formed envelope header", http.StatusBadRequest
+    }
+    if !validDsn(config.SentryDsnKey, header.Dsn) {
+        return "Invalid DSN", http.StatusUnauthorized
+    }
+    if debug {
+        log.Printf("envelope %s for %s: items %v", header.EventID, project, envelopeItemTypes(body[headerEnd:]))
+    }
+
+    spoolDir := filepath.Join(config.SpoolDir, project)
+    if err := os.MkdirAll(spoolDir, 0775); err != nil {
+        log.Printf("Error creating spool dir %s for %s: %s", spoolDir, project, err)
+        return "Could not spool envelope", http.StatusInternalServerError
+    }
+    // content-addressed so a retried POST of the same envelope spools once
+    sum := sha256.Sum256(body)
+    name := hex.EncodeToString(sum[:]) + ".envelope"
+    path := filepath.Join(spoolDir, name)
+    if err := ioutil.WriteFile(path, body, 0664); err != nil {
+        log.Printf("Error spooling envelope %s for %s: %s", name, project, err)
+        return "Could not spool envelope", http.StatusInternalServerError
+    }
+
+    ensureDiskStore(project, config)
+
+    return fmt.Sprintf(`{"id":%q}`, header.EventID), http.StatusOK
+}
+
+// validDsn checks the project's configured key is present in the DSN the
+// client sent, so a spoofed project name can't be used to fill someone
+// else's spool. An unconfigured key disables the check.
+func validDsn(want string, got string) bool {
+    if want == "" {
+        return true
+    }
+    return strings.Contains(got, want)
+}
+
+// envelopeItemTypes walks the item-header/item-payload pairs following the
+// envelope header, returning each item's declared type. This is only used
+// for logging - spooling and forwarding keep the envelope bytes intact so
+// nothing is lost if an item's shape is one we don't recognise.
+func envelopeItemTypes(rest []byte) []string {
+    var types []string
+    for len(rest) > 0 {
+        nl := bytes.IndexByte(rest, '\n')
+        var headerLine []byte
+        if nl < 0 {
+            headerLine, rest = rest, nil
+        } else {
+            headerLine, rest = rest[:nl], rest[nl+1:]
+        }
+        if len(headerLine) == 0 {
+            continue
+        }
+        var itemHeader SentryItemHeader
+        if err := json.Unmarshal(headerLine, &itemHeader); err != nil {
+            break
+        }
+        types = append(types, itemHeader.Type)
+        if itemHeader.Length > 0 {
+            if itemHeader.Length >= len(rest) {
+                rest = nil
+            } else {
+                rest = rest[itemHeader.Length:]
+                if len(rest) > 0 && rest[0] == '\n' {
+                    rest = rest[1:]
+                }
+            }
+        } else if nl2 := bytes.IndexByte(rest, '\n'); nl2 >= 0 {
+            rest = rest[nl2+1:]
+        } else {
+            rest = nil
+        }
+    }
+    return types
+}
+
+// readAppConfig loads conf/{appname}.json, the same config file
+// Logger.HandleRequest reads, without the logging-specific directory
+// bookkeeping Logger does.
+func readAppConfig(appname string) (LoggerConfig, error) {
+    raw, err := ioutil.ReadFile(filepath.Join(confdir, appname+".json"))
+    if err != nil {
+        return LoggerConfig{}, err
+    }
+    var config LoggerConfig
+    if err := json.Unmarshal(raw, &config); err != nil {
+        return LoggerConfig{}, err
+    }
+    return config, nil
+}
+
+const (
+    diskStoreMinBackoff     = 1 * time.Second
+    diskStoreMaxBackoff     = 5 * time.Minute
+    diskStoreDefaultSendRate = 1.0 // events/sec
+)
+
+// DiskStore sweeps one project's spool directory at SendRate, forwarding
+// each spooled envelope to the real upstream Sentry endpoint and deleting
+// it on success, so the service keeps accepting crashes even while
+// upstream is down or unreachable.
+type DiskStore struct {
+    project     string
+    dir         string
+    upstreamDsn string
+    upstreamURL string
+    sendRate    time.Duration
+    client      *http.Client
+
+    nextAttempt map[string]time.Time
+    backoff     map[string]time.Duration
+}
+
+func NewDiskStore(project string, config LoggerConfig) *DiskStore {
+    rate := config.SendRate
+    if rate <= 0 {
+        rate = diskStoreDefaultSendRate
+    }
+    upstreamURL, err := sentryEnvelopeEndpoint(config.UpstreamDsn)
+    if err != nil {
+        log.Printf("Could not derive upstream envelope endpoint for %s: %s", project, err)
+    }
+    return &DiskStore{
+        project:     project,
+        dir:         filepath.Join(config.SpoolDir, project),
+        upstreamDsn: config.UpstreamDsn,
+        upstreamURL: upstreamURL,
+        sendRate:    time.Duration(float64(time.Second) / rate),
+        client:      &http.Client{Timeout: 10 * time.Second},
+        nextAttempt: make(map[string]time.Time),
+        backoff:     make(map[string]time.Duration),
+    }
+}
+
+// sentryEnvelopeEndpoint turns a DSN like https://KEY@host/PROJECT_ID into
+// the envelope ingestion URL https://host/api/PROJECT_ID/envelope/.
+func sentryEnvelopeEndpoint(dsn string) (string, error) {
+    u, err := url.Parse(dsn)
+    if err != nil {
+        return "", err
+    }
+    projectID := strings.TrimPrefix(u.Path, "/")
+    if u.Host == "" || projectID == "" {
+        return "", fmt.Errorf("dsn %q is missing host or project id", dsn)
+    }
+    return fmt.Sprintf("%s://%s/api/%s/envelope/", u.Scheme, u.Host, projectID), nil
+}
+
+// Run sweeps the spool directory forever; call as a goroutine.
+func (this *DiskStore) Run() {
+    if this.upstreamURL == "" {
+        return
+    }
+    for {
+        this.sweep()
+        time.Sleep(this.sendRate)
+    }
+}
+
+func (this *DiskStore) sweep() {
+    entries, err := os.ReadDir(this.dir)
+    if err != nil {
+        log.Printf("Error listing spool dir %s for %s: %s", this.dir, this.project, err)
+        return
+    }
+    for _, e := range entries {
+        if e.IsDir() {
+            continue
+        }
+        this.forward(e.Name())
+    }
+}
+
+func (this *DiskStore) forward(name string) {
+    now := time.Now()
+    if next, waiting := this.nextAttempt[name]; waiting && now.Before(next) {
+        return
+    }
+    path := filepath.Join(this.dir, name)
+    body, err := ioutil.ReadFile(path)
+    if err != nil {
+        log.Printf("Error reading spooled envelope %s for %s: %s", name, this.project, err)
+        return
+    }
+    req, err := http.NewRequest(http.MethodPost, this.upstreamURL, bytes.NewReader(body))
+    if err != nil {
+        log.Printf("Error building upstream request for %s/%s: %s", this.project, name, err)
+        return
+    }
+    req.Header.Set("Content-Type", "application/x-sentry-envelope")
+    resp, err := this.client.Do(req)
+    if err != nil || resp.StatusCode >= 500 {
+        if err != nil {
+            log.Printf("Error forwarding %s/%s to %s: %s", this.project, name, this.upstreamURL, err)
+        } else {
+            resp.Body.Close()
+            log.Printf("Upstream %s rejected %s/%s with %d", this.upstreamURL, this.project, name, resp.StatusCode)
+        }
+        this.retryLater(name)
+        return
+    }
+    resp.Body.Close()
+    if resp.StatusCode >= 400 {
+        // a 4xx (bad DSN, malformed envelope, ...) won't succeed on
+        // retry, so log it loudly before giving up the spooled copy -
+        // this is the only trace that the envelope was ever dropped.
+        log.Printf("Upstream %s permanently rejected %s/%s with %d, discarding", this.upstreamURL, this.project, name, resp.StatusCode)
+    }
+    delete(this.nextAttempt, name)
+    delete(this.backoff, name)
+    if err := os.Remove(path); err != nil {
+        log.Printf("Error removing forwarded envelope %s/%s: %s", this.project, name, err)
+    }
+}
+
+func (this *DiskStore) retryLater(name string) {
+    backoff := this.backoff[name]
+    if backoff == 0 {
+        backoff = diskStoreMinBackoff
+    } else {
+        backoff *= 2
+        if backoff > diskStoreMaxBackoff {
+            backoff = diskStoreMaxBackoff
+        }
+    }
+    this.backoff[name] = backoff
+    this.nextAttempt[name] = time.Now().Add(backoff)
+}
+
+var envelopeStoresMu sync.Mutex
+var envelopeStores = make(map[string]*DiskStore)
+
+// ensureDiskStore starts a project's sweeper goroutine on first use,
+// mirroring how requestHandler lazily creates a Logger per app. If the
+// existing store's DSN failed to parse into an upstream URL (Run exits
+// immediately in that case), it's replaced once the config's
+// upstream_dsn changes, so fixing a bad DSN doesn't require a restart.
+func ensureDiskStore(project string, config LoggerConfig) {
+    if config.UpstreamDsn == "" {
+        return
+    }
+    envelopeStoresMu.Lock()
+    defer envelopeStoresMu.Unlock()
+    if existing, exists := envelopeStores[project]; exists {
+        if existing.upstreamURL != "" || existing.upstreamDsn == config.UpstreamDsn {
+            return
+        }
+    }
+    store := NewDiskStore(project, config)
+    envelopeStores[project] = store
+    go store.Run()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func newTestDiskStore(t *testing.T, upstreamURL string) *DiskStore {
+    t.Helper()
+    return &DiskStore{
+        project:     "proj",
+        dir:         t.TempDir(),
+        upstreamDsn: "https://key@example.test/1",
+        upstreamURL: upstreamURL,
+        client:      &http.Client{Timeout: 2 * time.Second},
+        nextAttempt: make(map[string]time.Time),
+        backoff:     make(map[string]time.Duration),
+    }
+}
+
+func writeSpoolFile(t *testing.T, dir, name, body string) string {
+    t.Helper()
+    path := filepath.Join(dir, name)
+    if err := os.WriteFile(path, []byte(body), 0664); err != nil {
+        t.Fatalf("writing spool file %s: %s", path, err)
+    }
+    return path
+}
+
+// TestDiskStoreForwardSuccessDeletesSpoolFile uses an httptest.Server as
+// the fake upstream Sentry endpoint and checks a 200 both forwards the
+// envelope body untouched and clears the spool file.
+func TestDiskStoreForwardSuccessDeletesSpoolFile(t *testing.T) {
+    var gotBody string
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        b, _ := io.ReadAll(r.Body)
+        gotBody = string(b)
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer srv.Close()
+
+    store := newTestDiskStore(t, srv.URL)
+    path := writeSpoolFile(t, store.dir, "abc.envelope", `{"event_id":"1"}`)
+
+    store.forward("abc.envelope")
+
+    if _, err := os.Stat(path); !os.IsNotExist(err) {
+        t.Fatalf("expected spool file to be removed after a successful forward, stat err = %v", err)
+    }
+    if gotBody != `{"event_id":"1"}` {
+        t.Fatalf("expected the spooled envelope body to be forwarded unchanged, got %q", gotBody)
+    }
+}
+
+// TestDiskStoreForwardRetriesOn5xx confirms a 5xx leaves the spool file in
+// place and schedules a retry, rather than losing the envelope.
+func TestDiskStoreForwardRetriesOn5xx(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusInternalServerError)
+    }))
+    defer srv.Close()
+
+    store := newTestDiskStore(t, srv.URL)
+    path := writeSpoolFile(t, store.dir, "retry.envelope", `{}`)
+
+    store.forward("retry.envelope")
+
+    if _, err := os.Stat(path); err != nil {
+        t.Fatalf("expected spool file to remain after a 5xx so it can be retried, got err %v", err)
+    }
+    if _, waiting := store.nextAttempt["retry.envelope"]; !waiting {
+        t.Fatalf("expected forward to schedule a retry after a 5xx")
+    }
+}
+
+// TestDiskStoreForwardDiscardsOn4xx confirms a permanent rejection (bad
+// DSN, malformed envelope) is discarded rather than retried forever.
+func TestDiskStoreForwardDiscardsOn4xx(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusBadRequest)
+    }))
+    defer srv.Close()
+
+    store := newTestDiskStore(t, srv.URL)
+    path := writeSpoolFile(t, store.dir, "bad.envelope", `{}`)
+
+    store.forward("bad.envelope")
+
+    if _, err := os.Stat(path); !os.IsNotExist(err) {
+        t.Fatalf("expected a permanently-rejected envelope to be discarded, not kept for retry")
+    }
+}
+
+// TestEnsureDiskStoreRecreatesAfterBadDsnFixed confirms a sweeper wedged
+// by an unparseable upstream_dsn is replaced once config is corrected,
+// instead of requiring a process restart.
+func TestEnsureDiskStoreRecreatesAfterBadDsnFixed(t *testing.T) {
+    project := "unwedge-test"
+    envelopeStoresMu.Lock()
+    delete(envelopeStores, project)
+    envelopeStoresMu.Unlock()
+
+    badConfig := LoggerConfig{SpoolDir: t.TempDir(), UpstreamDsn: "not-a-valid-dsn"}
+    ensureDiskStore(project, badConfig)
+    envelopeStoresMu.Lock()
+    first := envelopeStores[project]
+    envelopeStoresMu.Unlock()
+    if first == nil || first.upstreamURL != "" {
+        t.Fatalf("expected the bad DSN to produce a store with no upstream URL, got %+v", first)
+    }
+
+    goodConfig := badConfig
+    goodConfig.UpstreamDsn = "https://key@example.test/42"
+    ensureDiskStore(project, goodConfig)
+    envelopeStoresMu.Lock()
+    second := envelopeStores[project]
+    envelopeStoresMu.Unlock()
+    if second == first {
+        t.Fatalf("expected ensureDiskStore to replace a wedged store once the DSN is fixed")
+    }
+    if second.upstreamURL == "" {
+        t.Fatalf("expected the corrected DSN to produce a usable upstream URL")
+    }
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+    "encoding/json"
+)
+
+// fileSink is the original on-disk driver: an append-only file per app,
+// rotated out by a Rotator when it grows too large or old.
+type fileSink struct {
+    rotator *Rotator
+}
+
+func newFileSink(appname string, logdir string, opts map[string]interface{}, rotate RotatorConfig) (LogSink, error) {
+    return &fileSink{rotator: NewRotator(appname, logdir, rotate)}, nil
+}
+
+func (this *fileSink) WriteBatch(items []LoglevelItem) (int, error) {
+    total := 0
+    for i := range items {
+        buf, err := json.Marshal(items[i])
+        if err != nil {
+            return total, err
+        }
+        n, err := this.rotator.Write(buf)
+        total += n
+        if err != nil {
+            return total, err
+        }
+        n, err = this.rotator.Write([]byte("\n"))
+        total += n
+        if err != nil {
+            return total, err
+        }
+    }
+    return total, nil
+}
+
+func (this *fileSink) Flush() error {
+    return this.rotator.Sync()
+}
+
+func (this *fileSink) Close() error {
+    return this.rotator.Close()
+}
+
+// Path and BytesWritten implement Inspectable; ForceRotate implements
+// Rotatable. Both are used by the /admin/ API (see admin.go).
+func (this *fileSink) Path() string {
+    return this.rotator.Path()
+}
+
+func (this *fileSink) BytesWritten() int64 {
+    return this.rotator.BytesWritten()
+}
+
+func (this *fileSink) ForceRotate() error {
+    return this.rotator.ForceRotate()
+}
+
+func init() {
+    RegisterSink("file", newFileSink)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "cloud.google.com/go/storage"
+)
+
+// defaults for the gcs driver, overridable via driver_opts
+const (
+    gcsDefaultMaxBatchBytes = 1024 * 1024 // 1MB
+    gcsDefaultMaxBatchAge   = 30 * time.Second
+)
+
+// gcsSink buffers items in memory and uploads them as a single object
+// once MaxBatchBytes or MaxBatchAge is exceeded, trading a small durability
+// window for far fewer, larger writes than one object per item. A
+// background goroutine also flushes on MaxBatchAge regardless of new
+// writes, so a low-traffic app's buffered items don't sit in memory
+// indefinitely waiting for the next WriteBatch call.
+type gcsSink struct {
+    appname       string
+    bucket        string
+    prefix        string
+    maxBatchBytes int
+    maxBatchAge   time.Duration
+
+    client *storage.Client
+
+    mu     sync.Mutex
+    buf    bytes.Buffer
+    opened time.Time
+
+    stop chan struct{}
+}
+
+func newGCSSink(appname string, logdir string, opts map[string]interface{}, rotate RotatorConfig) (LogSink, error) {
+    bucket, _ := opts["bucket"].(string)
+    if bucket == "" {
+        return nil, fmt.Errorf("gcs driver requires a bucket option")
+    }
+    prefix, _ := opts["prefix"].(string)
+
+    client, err := storage.NewClient(context.Background())
+    if err != nil {
+        return nil, fmt.Errorf("gcs client: %s", err)
+    }
+    sink := &gcsSink{
+        appname:       appname,
+        bucket:        bucket,
+        prefix:        prefix,
+        maxBatchBytes: gcsDefaultMaxBatchBytes,
+        maxBatchAge:   gcsDefaultMaxBatchAge,
+        client:        client,
+        stop:          make(chan struct{}),
+    }
+    go sink.ageFlushLoop()
+    return sink, nil
+}
+
+// ageFlushLoop flushes on maxBatchAge on a timer, independent of
+// WriteBatch, so a batch isn't left buffered forever once traffic for
+// an app goes quiet.
+func (this *gcsSink) ageFlushLoop() {
+    ticker := time.NewTicker(this.maxBatchAge)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ticker.C:
+            if err := this.Flush(); err != nil {
+                log.Printf("Error age-flushing gcs sink for %s: %s", this.appname, err)
+            }
+        case <-this.stop:
+            return
+        }
+    }
+}
+
+func (this *gcsSink) WriteBatch(items []LoglevelItem) (int, error) {
+    this.mu.Lock()
+    defer this.mu.Unlock()
+    if this.buf.Len() == 0 {
+        this.opened = time.Now()
+    }
+    total := 0
+    for i := range items {
+        buf, err := json.Marshal(items[i])
+        if err != nil {
+            return total, err
+        }
+        this.buf.Write(buf)
+        this.buf.WriteByte('\n')
+        total += len(buf) + 1
+    }
+    if this.buf.Len() >= this.maxBatchBytes || time.Since(this.opened) > this.maxBatchAge {
+        return total, this.flushLocked()
+    }
+    return total, nil
+}
+
+func (this *gcsSink) Flush() error {
+    this.mu.Lock()
+    defer this.mu.Unlock()
+    return this.flushLocked()
+}
+
+func (this *gcsSink) flushLocked() error {
+    if this.buf.Len() == 0 {
+        return nil
+    }
+    name := fmt.Sprintf("%s%s-%d.log", this.prefix, this.appname, time.Now().UnixNano())
+    ctx := context.Background()
+    w := this.client.Bucket(this.bucket).Object(name).NewWriter(ctx)
+    if _, err := w.Write(this.buf.Bytes()); err != nil {
+        w.Close()
+        return err
+    }
+    if err := w.Close(); err != nil {
+        return err
+    }
+    log.Printf("Flushed %d bytes to gs://%s/%s for %s", this.buf.Len(), this.bucket, name, this.appname)
+    this.buf.Reset()
+    return nil
+}
+
+func (this *gcsSink) Close() error {
+    close(this.stop)
+    err := this.Flush()
+    if cerr := this.client.Close(); err == nil {
+        err = cerr
+    }
+    return err
+}
+
+func init() {
+    RegisterSink("gcs", newGCSSink)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+    "encoding/json"
+    "os"
+)
+
+// streamSink writes items straight to a process stream (stdout/stderr),
+// one JSON object per line. Useful for containerized deployments that
+// collect logs from the container runtime instead of a mounted volume.
+type streamSink struct {
+    out *os.File
+}
+
+func (this *streamSink) WriteBatch(items []LoglevelItem) (int, error) {
+    total := 0
+    for i := range items {
+        buf, err := json.Marshal(items[i])
+        if err != nil {
+            return total, err
+        }
+        n, err := this.out.Write(buf)
+        total += n
+        if err != nil {
+            return total, err
+        }
+        n, err = this.out.Write([]byte("\n"))
+        total += n
+        if err != nil {
+            return total, err
+        }
+    }
+    return total, nil
+}
+
+func (this *streamSink) Flush() error {
+    return nil
+}
+
+func (this *streamSink) Close() error {
+    return nil
+}
+
+func init() {
+    RegisterSink("stdout", func(appname string, logdir string, opts map[string]interface{}, rotate RotatorConfig) (LogSink, error) {
+        return &streamSink{out: os.Stdout}, nil
+    })
+    RegisterSink("stderr", func(appname string, logdir string, opts map[string]interface{}, rotate RotatorConfig) (LogSink, error) {
+        return &streamSink{out: os.Stderr}, nil
+    })
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+    "fmt"
+)
+
+// LogSink is the write destination for a logger's items. Implementations
+// decide how (and where) items end up durable - a local file, stdout, or
+// a batched upload to cloud storage - so Logger.HandleRequest does not
+// need to know which.
+type LogSink interface {
+    // WriteBatch appends the given items to the sink, returning the
+    // number of bytes written (for the httplog_bytes_written_total
+    // metric) even when it also returns an error.
+    WriteBatch(items []LoglevelItem) (int, error)
+    // Flush forces any buffered items to be made durable.
+    Flush() error
+    // Close flushes and releases any resources held by the sink.
+    Close() error
+}
+
+// Inspectable is implemented by sinks that can report a current backing
+// file and bytes written to it, for the /admin/loggers listing and tail
+// endpoints. Sinks without a local file (stdout, gcs) don't implement it.
+type Inspectable interface {
+    Path() string
+    BytesWritten() int64
+}
+
+// Rotatable is implemented by sinks that support an explicit, immediate
+// rotation, for the /admin/loggers/{app}/rotate endpoint.
+type Rotatable interface {
+    ForceRotate() error
+}
+
+// SinkFactory builds a LogSink for an app, given the app's log directory
+// (where relevant, e.g. for the file driver), the driver_opts from
+// LoggerConfig, and the app's rotation settings. Drivers that don't
+// write local files (stdout, gcs) are free to ignore rotate.
+type SinkFactory func(appname string, logdir string, opts map[string]interface{}, rotate RotatorConfig) (LogSink, error)
+
+var sinks = make(map[string]SinkFactory)
+
+// RegisterSink makes a driver available for use via LoggerConfig.Driver.
+// Call from an init() function; panics on duplicate registration, so
+// drivers can be added by just importing their package.
+func RegisterSink(name string, factory SinkFactory) {
+    if _, exists := sinks[name]; exists {
+        panic(fmt.Sprintf("sink driver %q already registered", name))
+    }
+    sinks[name] = factory
+}
+
+// newSink looks up the registered driver and builds a sink for it,
+// defaulting to the "file" driver to match pre-driver behaviour.
+func newSink(driver string, appname string, logdir string, opts map[string]interface{}, rotate RotatorConfig) (LogSink, error) {
+    if driver == "" {
+        driver = "file"
+    }
+    factory, ok := sinks[driver]
+    if !ok {
+        return nil, fmt.Errorf("unknown log driver %q", driver)
+    }
+    return factory(appname, logdir, opts, rotate)
+}
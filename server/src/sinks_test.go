@@ -0,0 +1,114 @@
+package main
+
+import (
+    "net/http"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+// fakeSink is a LogSink that just records what it was handed, so
+// Logger.HandleRequest can be tested without touching disk or GCS.
+type fakeSink struct {
+    mu       sync.Mutex
+    batches  [][]LoglevelItem
+    writeErr error
+}
+
+func (this *fakeSink) WriteBatch(items []LoglevelItem) (int, error) {
+    this.mu.Lock()
+    defer this.mu.Unlock()
+    if this.writeErr != nil {
+        return 0, this.writeErr
+    }
+    cp := append([]LoglevelItem(nil), items...)
+    this.batches = append(this.batches, cp)
+    n := 0
+    for i := range items {
+        n += len(items[i].Message)
+    }
+    return n, nil
+}
+
+func (this *fakeSink) Flush() error { return nil }
+func (this *fakeSink) Close() error { return nil }
+
+// TestHandleRequestWritesThroughSink is a unit test of Logger.HandleRequest
+// against a fakeSink: it shouldn't care which LogSink implementation it's
+// handed, only that items reach WriteBatch once auth/config checks pass.
+func TestHandleRequestWritesThroughSink(t *testing.T) {
+    fake := &fakeSink{}
+    logger := &Logger{
+        Appname:         "unit-app",
+        Configured:      true,
+        ConfigLastCheck: time.Now(),
+        Config:          LoggerConfig{Secret: "tok"},
+        Sink:            fake,
+    }
+    items := []LoglevelItem{{Message: "hello", Level: "info"}}
+
+    msg, code := logger.HandleRequest(LogRequest{Appname: "unit-app", Token: "tok", Items: items})
+    if code != http.StatusOK {
+        t.Fatalf("expected 200, got %d (%s)", code, msg)
+    }
+    if len(fake.batches) != 1 || len(fake.batches[0]) != 1 || fake.batches[0][0].Message != "hello" {
+        t.Fatalf("expected the item to reach WriteBatch, got %+v", fake.batches)
+    }
+}
+
+// TestHandleRequestRejectsBadTokenWithoutTouchingSink confirms a bad token
+// is rejected before ever reaching the sink.
+func TestHandleRequestRejectsBadTokenWithoutTouchingSink(t *testing.T) {
+    fake := &fakeSink{}
+    logger := &Logger{
+        Appname:         "unit-app-badtoken",
+        Configured:      true,
+        ConfigLastCheck: time.Now(),
+        Config:          LoggerConfig{Secret: "tok"},
+        Sink:            fake,
+    }
+    _, code := logger.HandleRequest(LogRequest{Appname: "unit-app-badtoken", Token: "wrong", Items: []LoglevelItem{{Message: "hi"}}})
+    if code != http.StatusUnauthorized {
+        t.Fatalf("expected 401 for a bad token, got %d", code)
+    }
+    if len(fake.batches) != 0 {
+        t.Fatalf("expected no items to reach the sink, got %+v", fake.batches)
+    }
+}
+
+// TestFileSinkIntegration exercises the real file driver end to end:
+// WriteBatch -> Rotator -> disk, and back out again via a plain read.
+func TestFileSinkIntegration(t *testing.T) {
+    dir := t.TempDir()
+    sink, err := newFileSink("intapp", dir, nil, RotatorConfig{})
+    if err != nil {
+        t.Fatalf("newFileSink: %s", err)
+    }
+    items := []LoglevelItem{
+        {Message: "first", Level: "info"},
+        {Message: "second", Level: "warn"},
+    }
+    n, err := sink.WriteBatch(items)
+    if err != nil {
+        t.Fatalf("WriteBatch: %s", err)
+    }
+    if n == 0 {
+        t.Fatalf("expected WriteBatch to report bytes written, got 0")
+    }
+    if err := sink.Close(); err != nil {
+        t.Fatalf("Close: %s", err)
+    }
+
+    data, err := os.ReadFile(filepath.Join(dir, "intapp.log"))
+    if err != nil {
+        t.Fatalf("reading log file: %s", err)
+    }
+    for _, want := range []string{`"message":"first"`, `"message":"second"`} {
+        if !strings.Contains(string(data), want) {
+            t.Fatalf("expected log file to contain %q, got %q", want, data)
+        }
+    }
+}